@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authHandshake is the literal prefix a client must send, immediately
+// followed by its token, before any other traffic.
+const authHandshake = "::auth::"
+
+// authConfig is the shared secret (or bcrypt hash of one) listeners
+// require before accepting a connection's traffic.
+type authConfig struct {
+	token    string
+	hashFile string
+}
+
+// required reports whether connections must complete the ::auth::
+// handshake before anything else is read from them.
+func (a authConfig) required() bool {
+	return a.token != "" || a.hashFile != ""
+}
+
+// verify checks a raw inbound line against the configured secret. line is
+// expected to be "::auth::<token>", already stripped of its trailing
+// newline.
+func (a authConfig) verify(line string) bool {
+	candidate, ok := strings.CutPrefix(line, authHandshake)
+	if !ok {
+		return false
+	}
+
+	if a.hashFile != "" {
+		hash, err := os.ReadFile(a.hashFile)
+		if err != nil {
+			return false
+		}
+		return bcrypt.CompareHashAndPassword(bytes.TrimSpace(hash), []byte(candidate)) == nil
+	}
+
+	return subtle.ConstantTimeCompare([]byte(candidate), []byte(a.token)) == 1
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// batchInterval is how often pending messages are coalesced into a single
+// batchMessage tea.Msg, to keep the renderer from flickering or falling
+// behind under a high-rate producer.
+const batchInterval = 16 * time.Millisecond
+
+// dropPolicy controls what batcher.push does once its buffer is full.
+type dropPolicy string
+
+const (
+	dropOldest dropPolicy = "oldest"
+	dropNewest dropPolicy = "newest"
+	dropBlock  dropPolicy = "block"
+)
+
+// batcher is a per-run ring buffer that every listener goroutine pushes
+// remoteMessages into; a single ticker-driven goroutine drains it into
+// batchMessage events so Update only re-renders once per tick, no matter
+// how many connections are producing traffic.
+type batcher struct {
+	ch     chan remoteMessage
+	policy dropPolicy
+}
+
+func newBatcher(maxBuffer int, policy dropPolicy) *batcher {
+	return &batcher{ch: make(chan remoteMessage, maxBuffer), policy: policy}
+}
+
+// push enqueues msg, applying the configured drop policy if the buffer is
+// full: "block" waits for room, "newest" discards msg itself, and
+// "oldest" discards the longest-queued message to make room.
+func (b *batcher) push(msg remoteMessage) {
+	switch b.policy {
+	case dropBlock:
+		b.ch <- msg
+
+	case dropNewest:
+		select {
+		case b.ch <- msg:
+		default:
+		}
+
+	default: // dropOldest
+		for {
+			select {
+			case b.ch <- msg:
+				return
+			default:
+				select {
+				case <-b.ch:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// batchMessage is a coalesced group of remoteMessages, all received since
+// the last tick.
+type batchMessage []remoteMessage
+
+// startBatcher drains b every batchInterval, sending whatever accumulated
+// as a single batchMessage so the TUI re-renders once per tick instead of
+// once per message.
+func startBatcher(p *tea.Program, b *batcher) {
+	go func() {
+		ticker := time.NewTicker(batchInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			var pending []remoteMessage
+		drain:
+			for {
+				select {
+				case msg := <-b.ch:
+					pending = append(pending, msg)
+				default:
+					break drain
+				}
+			}
+
+			if len(pending) > 0 {
+				p.Send(batchMessage(pending))
+			}
+		}
+	}()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// protocol is the transport a listener accepts connections over.
+type protocol string
+
+const (
+	protoTCP  protocol = "tcp"
+	protoTLS  protocol = "tls"
+	protoUDP  protocol = "udp"
+	protoUnix protocol = "unix"
+)
+
+// listenerConfig describes one named listener: the address it binds (a
+// port for tcp/tls/udp, a socket path for unix), the transport protocol,
+// and the log format its input should be parsed as ("auto" detects
+// per-line).
+type listenerConfig struct {
+	name   string
+	addr   string
+	format string
+	proto  protocol
+}
+
+// listenFlags collects repeated -listen flags, e.g.:
+//
+//	-listen api=21212 -listen worker=21213:json:tls -listen local=/tmp/dl.sock::unix
+type listenFlags []listenerConfig
+
+func (l *listenFlags) String() string {
+	parts := make([]string, len(*l))
+	for i, cfg := range *l {
+		parts[i] = fmt.Sprintf("%s=%s:%s:%s", cfg.name, cfg.addr, cfg.format, cfg.proto)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *listenFlags) Set(value string) error {
+	name, rest, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("invalid -listen value %q, want name=addr[:format[:proto]]", value)
+	}
+
+	fields := strings.Split(rest, ":")
+	cfg := listenerConfig{name: name, addr: fields[0], format: "auto", proto: protoTCP}
+	if len(fields) > 1 && fields[1] != "" {
+		cfg.format = fields[1]
+	}
+	if len(fields) > 2 && fields[2] != "" {
+		cfg.proto = protocol(fields[2])
+	}
+
+	switch cfg.proto {
+	case protoTCP, protoTLS, protoUDP:
+		if _, err := strconv.Atoi(cfg.addr); err != nil {
+			return fmt.Errorf("invalid -listen port %q: %w", cfg.addr, err)
+		}
+	case protoUnix:
+		// addr is a socket path; any non-empty value is acceptable.
+		if cfg.addr == "" {
+			return fmt.Errorf("invalid -listen socket path: empty")
+		}
+	default:
+		return fmt.Errorf("invalid -listen protocol %q, want tcp, tls, udp, or unix", cfg.proto)
+	}
+
+	*l = append(*l, cfg)
+	return nil
+}
+
+// appConfig is the fully parsed command line: which ports to listen on,
+// the TLS/auth material those listeners share, and optionally a prior
+// session to replay on launch.
+type appConfig struct {
+	listeners  []listenerConfig
+	replayPath string
+	replayFast bool
+	auth       authConfig
+	tls        tlsConfig
+	socketMode os.FileMode
+	maxBuffer  int
+	dropPolicy dropPolicy
+}
+
+// parseAppConfig reads -listen, -replay, -replay-fast, TLS, and auth flags
+// from the command line, falling back to the single default listener
+// (port 21212, auto-detected format, plain tcp) when no -listen flags are
+// given.
+func parseAppConfig(args []string) (appConfig, error) {
+	var listeners listenFlags
+
+	fs := flag.NewFlagSet("debug-listener", flag.ContinueOnError)
+	fs.Var(&listeners, "listen", "named listener as name=addr[:format[:proto]], may be repeated")
+	replay := fs.String("replay", "", "path to a prior session (.ndjson) to replay on launch")
+	replayFast := fs.Bool("replay-fast", false, "replay the session instantly instead of at its original pace")
+	certFile := fs.String("cert", "", "TLS certificate file, required for tls listeners")
+	keyFile := fs.String("key", "", "TLS private key file, required for tls listeners")
+	clientCA := fs.String("client-ca", "", "CA file to require and verify client certificates against (mutual TLS)")
+	authToken := fs.String("auth-token", "", "shared secret clients must send as ::auth::<token> before any other traffic")
+	authTokenHashFile := fs.String("auth-token-hash-file", "", "file containing a bcrypt hash to verify the client's ::auth:: token against, instead of -auth-token")
+	socketMode := fs.Uint("unix-socket-mode", 0600, "file mode applied to unix-socket listeners")
+	maxBuffer := fs.Int("max-buffer", 4096, "max pending messages buffered for the renderer before the drop policy kicks in")
+	dropPolicyFlag := fs.String("drop-policy", string(dropOldest), "what to do when -max-buffer is exceeded: oldest, newest, or block")
+	if err := fs.Parse(args); err != nil {
+		return appConfig{}, err
+	}
+
+	if len(listeners) == 0 {
+		listeners = append(listeners, listenerConfig{name: "", addr: connPort, format: "auto", proto: protoTCP})
+	}
+
+	policy := dropPolicy(*dropPolicyFlag)
+	switch policy {
+	case dropOldest, dropNewest, dropBlock:
+	default:
+		return appConfig{}, fmt.Errorf("invalid -drop-policy %q, want oldest, newest, or block", *dropPolicyFlag)
+	}
+
+	if *maxBuffer <= 0 {
+		return appConfig{}, fmt.Errorf("invalid -max-buffer %d, want a positive number of messages", *maxBuffer)
+	}
+
+	return appConfig{
+		listeners:  listeners,
+		replayPath: *replay,
+		replayFast: *replayFast,
+		auth:       authConfig{token: *authToken, hashFile: *authTokenHashFile},
+		tls:        tlsConfig{certFile: *certFile, keyFile: *keyFile, clientCAFile: *clientCA},
+		socketMode: os.FileMode(*socketMode),
+		maxBuffer:  *maxBuffer,
+		dropPolicy: policy,
+	}, nil
+}
+
+func mustParseAppConfig(args []string) appConfig {
+	cfg, err := parseAppConfig(args)
+	if err != nil {
+		fmt.Println("Error parsing flags:", err.Error())
+		os.Exit(1)
+	}
+	return cfg
+}
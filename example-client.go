@@ -2,6 +2,9 @@ package main
 
 import (
 	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
 	"fmt"
 	"net"
 	"os"
@@ -11,26 +14,34 @@ import (
 const (
 	connectHost = "localhost"
 	connectPort = "21212"
-	connectType = "tcp"
 )
 
 func main() {
-	// Start the client and connect to the server.
-	fmt.Println("Connecting to", connectType, "server", connectHost+":"+connectPort)
-	conn, err := net.Dial(connectType, connectHost+":"+connectPort)
+	name := flag.String("name", "", "name to announce via ::name:: before sending any messages")
+	useTLS := flag.Bool("tls", false, "connect over TLS instead of plain TCP")
+	caFile := flag.String("ca", "", "CA file to verify the server's certificate against, for self-signed servers")
+	unixSocket := flag.String("unix", "", "connect to a unix socket at this path instead of host:port")
+	authToken := flag.String("auth-token", "", "shared secret to send as ::auth::<token> before any other traffic")
+	flag.Parse()
+
+	conn, err := dial(*useTLS, *caFile, *unixSocket)
 	if err != nil {
 		fmt.Println("Error connecting:", err.Error())
 		os.Exit(1)
 	}
 
-	// Create new reader from Stdin.
-	reader := bufio.NewReader(os.Stdin)
+	if *authToken != "" {
+		conn.Write([]byte("::auth::" + *authToken + "\n"))
+	}
 
-	if len(os.Args) > 1 {
-		fmt.Println("Setting name to", os.Args[1])
-		conn.Write([]byte("::name::" + os.Args[1] + "\n"))
+	if *name != "" {
+		fmt.Println("Setting name to", *name)
+		conn.Write([]byte("::name::" + *name + "\n"))
 	}
 
+	// Create new reader from Stdin.
+	reader := bufio.NewReader(os.Stdin)
+
 	// run loop forever, until exit.
 	for {
 		// Prompting message.
@@ -43,3 +54,34 @@ func main() {
 		conn.Write([]byte(input))
 	}
 }
+
+// dial connects to the debug listener using whichever transport the flags
+// selected: a unix socket, TLS, or plain TCP.
+func dial(useTLS bool, caFile string, unixSocket string) (net.Conn, error) {
+	if unixSocket != "" {
+		fmt.Println("Connecting to unix socket", unixSocket)
+		return net.Dial("unix", unixSocket)
+	}
+
+	addr := connectHost + ":" + connectPort
+	if !useTLS {
+		fmt.Println("Connecting to tcp server", addr)
+		return net.Dial("tcp", addr)
+	}
+
+	fmt.Println("Connecting to tls server", addr)
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tls.Dial("tcp", addr, tlsConfig)
+}
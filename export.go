@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// saveStatus formats the footer message reporting where a save/export
+// landed, or why it failed.
+func saveStatus(verb, path string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%s failed: %s", verb, err.Error())
+	}
+	return fmt.Sprintf("%s to %s", verb, path)
+}
+
+// ansiEscape matches one SGR escape sequence, e.g. "\x1b[1;38;5;3m".
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// exportDir is where saved/exported buffers land: ~/.debug-listener/exports.
+func exportDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".debug-listener", "exports")
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// stripANSI removes SGR escape sequences, leaving plain text.
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// saveText writes content, with styling escapes stripped, to a timestamped
+// .txt file under exportDir and returns the path written.
+func saveText(content string, ext string, now time.Time) (string, error) {
+	dir, err := exportDir()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, now.Format("2006-01-02T15-04-05")+ext)
+	return path, os.WriteFile(path, []byte(stripANSI(content)), 0o644)
+}
+
+// saveHTML writes content to a timestamped .html file, converting its SGR
+// escape sequences into inline-styled <span> tags so the rendered colors
+// survive outside the terminal.
+func saveHTML(content string, now time.Time) (string, error) {
+	dir, err := exportDir()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, now.Format("2006-01-02T15-04-05")+".html")
+	body := fmt.Sprintf("<html><body><pre style=\"background:#000;color:#ddd;font-family:monospace\">%s</pre></body></html>", ansiToHTML(content))
+	return path, os.WriteFile(path, []byte(body), 0o644)
+}
+
+// ansiToHTML converts the handful of SGR codes this program actually emits
+// (bold, reverse video, and our 4-bit palette colors) into nested spans; it
+// is not a general-purpose ANSI renderer.
+func ansiToHTML(s string) string {
+	var sb strings.Builder
+	openSpans := 0
+
+	pieces := ansiEscape.Split(s, -1)
+	codes := ansiEscape.FindAllString(s, -1)
+
+	for i, piece := range pieces {
+		sb.WriteString(html.EscapeString(piece))
+		if i >= len(codes) {
+			continue
+		}
+
+		style := sgrToCSS(codes[i])
+		if style == "" {
+			continue
+		}
+		if style == "reset" {
+			for ; openSpans > 0; openSpans-- {
+				sb.WriteString("</span>")
+			}
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf(`<span style="%s">`, style))
+		openSpans++
+	}
+
+	for ; openSpans > 0; openSpans-- {
+		sb.WriteString("</span>")
+	}
+
+	return sb.String()
+}
+
+var ansiColorCSS = map[string]string{
+	"1":  "#c0392b",
+	"2":  "#27ae60",
+	"3":  "#f1c40f",
+	"4":  "#2980b9",
+	"5":  "#8e44ad",
+	"6":  "#16a085",
+	"8":  "#7f8c8d",
+	"9":  "#e74c3c",
+	"13": "#d980fa",
+	"14": "#00cec9",
+}
+
+// sgrToCSS turns one "\x1b[...m" sequence into an inline style string, the
+// literal "reset" for a bare/zero code, or "" if we don't render it.
+func sgrToCSS(code string) string {
+	body := strings.TrimSuffix(strings.TrimPrefix(code, "\x1b["), "m")
+	if body == "" || body == "0" {
+		return "reset"
+	}
+
+	var styles []string
+	parts := strings.Split(body, ";")
+	for i := 0; i < len(parts); i++ {
+		switch parts[i] {
+		case "1":
+			styles = append(styles, "font-weight:bold")
+		case "7":
+			styles = append(styles, "filter:invert(1)")
+		case "38":
+			if i+2 < len(parts) && parts[i+1] == "5" {
+				if css, ok := ansiColorCSS[parts[i+2]]; ok {
+					styles = append(styles, "color:"+css)
+				}
+				i += 2
+			}
+		default:
+			key := ""
+			if n, err := strconv.Atoi(parts[i]); err == nil {
+				switch {
+				case n >= 30 && n <= 37:
+					key = strconv.Itoa(n - 30)
+				case n >= 90 && n <= 97:
+					key = strconv.Itoa(n - 90 + 8)
+				}
+			}
+			if css, ok := ansiColorCSS[key]; ok {
+				styles = append(styles, "color:"+css)
+			}
+		}
+	}
+
+	return strings.Join(styles, ";")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// sourceKey returns the 1-9 toggle key assigned to name by order of first
+// appearance, or 0 if name hasn't been seen (or ran out of keys).
+func (m *model) sourceKey(name string) int {
+	for i, seen := range m.sourceOrder {
+		if seen == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// trackSource records name the first time it's seen, up to the 9 slots the
+// numeric toggle keys can address.
+func (m *model) trackSource(name string) {
+	if m.sourceKey(name) != 0 || len(m.sourceOrder) >= 9 {
+		return
+	}
+	m.sourceOrder = append(m.sourceOrder, name)
+}
+
+// toggleSource shows/hides the connection bound to numeric key n (1-9).
+func (m *model) toggleSource(n int) {
+	if n < 1 || n > len(m.sourceOrder) {
+		return
+	}
+	name := m.sourceOrder[n-1]
+	if m.hiddenSources == nil {
+		m.hiddenSources = map[string]bool{}
+	}
+	m.hiddenSources[name] = !m.hiddenSources[name]
+}
+
+func sourceName(msg remoteMessage) string {
+	return strings.TrimSuffix(msg.name, ": ")
+}
+
+// visible reports whether a message from the given source should appear in
+// the detail pane: it must not be hidden via the 1-9 toggles, and if a
+// source is pinned, it must match it.
+func (m *model) visible(name string) bool {
+	if m.hiddenSources[name] {
+		return false
+	}
+	if m.pinnedSource != "" && name != m.pinnedSource {
+		return false
+	}
+	return true
+}
+
+// recomputeMatches rebuilds m.matches (indices into m.messages) for the
+// currently committed filter, skipping hidden sources.
+func (m *model) recomputeMatches() {
+	m.matches = nil
+	m.matchCursor = 0
+
+	if m.filterMode == "" || m.filterQuery == "" {
+		return
+	}
+
+	switch m.filterMode {
+	case "substring":
+		re, _ := regexp.Compile(m.filterQuery)
+		for i, msg := range m.messages {
+			if !m.visible(sourceName(msg)) {
+				continue
+			}
+			if re != nil && re.MatchString(msg.message) {
+				m.matches = append(m.matches, i)
+			} else if re == nil && strings.Contains(msg.message, m.filterQuery) {
+				m.matches = append(m.matches, i)
+			}
+		}
+
+	case "fuzzy":
+		var candidates []string
+		var indices []int
+		for i, msg := range m.messages {
+			if !m.visible(sourceName(msg)) {
+				continue
+			}
+			candidates = append(candidates, msg.message)
+			indices = append(indices, i)
+		}
+		for _, result := range fuzzy.Find(m.filterQuery, candidates) {
+			m.matches = append(m.matches, indices[result.Index])
+		}
+	}
+}
+
+// renderAll rebuilds the viewport content from the full message log,
+// applying source visibility toggles and highlighting filter matches.
+func (m *model) renderAll() string {
+	matchSet := make(map[int]bool, len(m.matches))
+	for _, idx := range m.matches {
+		matchSet[idx] = true
+	}
+
+	var sb strings.Builder
+	line := 0
+	m.matchLines = m.matchLines[:0]
+
+	for i, msg := range m.messages {
+		if !m.visible(sourceName(msg)) {
+			continue
+		}
+
+		highlighted := matchSet[i]
+		rendered := m.renderMessage(msg, highlighted)
+		if highlighted {
+			m.matchLines = append(m.matchLines, line)
+		}
+
+		sb.WriteString(rendered)
+		line += strings.Count(rendered, "\n")
+	}
+
+	return sb.String()
+}
+
+// filteredContent renders only the currently matched (and visible)
+// messages, for "e" export. With no active filter it's the same as the
+// full rendered buffer.
+func (m *model) filteredContent() string {
+	if m.filterMode == "" || m.filterQuery == "" {
+		return m.content
+	}
+
+	matchSet := make(map[int]bool, len(m.matches))
+	for _, idx := range m.matches {
+		matchSet[idx] = true
+	}
+
+	var sb strings.Builder
+	for i, msg := range m.messages {
+		if matchSet[i] && m.visible(sourceName(msg)) {
+			sb.WriteString(m.renderMessage(msg, false))
+		}
+	}
+	return sb.String()
+}
+
+// jumpToMatch scrolls the viewport so matchLines[m.matchCursor] is visible.
+func (m *model) jumpToMatch() {
+	if len(m.matchLines) == 0 {
+		return
+	}
+	m.viewport.SetYOffset(m.matchLines[m.matchCursor])
+}
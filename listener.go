@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/csterritt/debug-listener/store"
+)
+
+// tlsConfig is the certificate material shared by every tls listener.
+type tlsConfig struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+}
+
+// buildTLSConfig loads cfg's certificate (and, if set, client CA) into a
+// *tls.Config suitable for tls.Listen.
+func buildTLSConfig(cfg tlsConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.certFile, cfg.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS cert/key: %w", err)
+	}
+
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if cfg.clientCAFile != "" {
+		pem, err := os.ReadFile(cfg.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.clientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsCfg, nil
+}
+
+// startListener binds each configured listener and dispatches accepted
+// connections to handleConnection, tagged with that listener's name and
+// format. Listeners that require TLS or auth material enforce it before
+// any application traffic is processed.
+func startListener(p *tea.Program, configs []listenerConfig, auth authConfig, tlsCfg tlsConfig, socketMode os.FileMode) {
+	for _, cfg := range configs {
+		cfg := cfg
+		switch cfg.proto {
+		case protoUDP:
+			go serveUDP(p, cfg, auth)
+		case protoUnix:
+			go serveStream(p, cfg, auth, nil, socketMode)
+		case protoTLS:
+			conf, err := buildTLSConfig(tlsCfg)
+			if err != nil {
+				fmt.Println("Error configuring TLS:", err.Error())
+				os.Exit(1)
+			}
+			go serveStream(p, cfg, auth, conf, 0)
+		default:
+			go serveStream(p, cfg, auth, nil, 0)
+		}
+	}
+}
+
+// serveStream binds a stream-oriented listener (tcp, tls, or unix) and
+// accepts connections until the listener errors out.
+func serveStream(p *tea.Program, cfg listenerConfig, auth authConfig, tlsCfg *tls.Config, socketMode os.FileMode) {
+	var (
+		l   net.Listener
+		err error
+	)
+
+	switch cfg.proto {
+	case protoUnix:
+		os.Remove(cfg.addr)
+		l, err = net.Listen("unix", cfg.addr)
+		if err == nil {
+			err = os.Chmod(cfg.addr, socketMode)
+		}
+	case protoTLS:
+		l, err = tls.Listen("tcp", connHost+":"+cfg.addr, tlsCfg)
+	default:
+		l, err = net.Listen("tcp", connHost+":"+cfg.addr)
+	}
+
+	if err != nil {
+		fmt.Println("Error listening:", err.Error())
+		os.Exit(1)
+	}
+	defer l.Close()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			fmt.Println("Error connecting:", err.Error())
+			return
+		}
+
+		go handleConnection(c, p, cfg, auth)
+	}
+}
+
+// handleConnection optionally gates the connection behind the ::auth::
+// handshake, then reads newline-framed lines from it until EOF.
+func handleConnection(conn net.Conn, p *tea.Program, cfg listenerConfig, auth authConfig) {
+	reader := bufio.NewReader(conn)
+	src := sourceConn{addr: conn.RemoteAddr().String(), name: cfg.name, format: cfg.format}
+
+	if auth.required() {
+		line, err := reader.ReadString('\n')
+		if err != nil || !auth.verify(strings.TrimSpace(line)) {
+			conn.Close()
+			return
+		}
+	}
+
+	p.Send(sourceConnected{addr: src.addr, name: displayName(src)})
+	defer p.Send(sourceDisconnected{addr: src.addr})
+
+	for {
+		buffer, err := reader.ReadBytes('\n')
+
+		if err != nil {
+			conn.Close()
+			return
+		}
+
+		str := string(buffer[:len(buffer)-1])
+		if index := strings.Index(str, "::name::"); index != -1 {
+			src.name = strings.TrimSpace(str[index+8:])
+			p.Send(sourceConnected{addr: src.addr, name: displayName(src)})
+			continue
+		}
+
+		dispatchLine(src, str)
+	}
+}
+
+// displayName is the name shown for a source in the list pane: its chosen
+// name if one's been set, otherwise its peer address.
+func displayName(src sourceConn) string {
+	if src.name != "" {
+		return src.name
+	}
+	return src.addr
+}
+
+// serveUDP reads datagrams, each one framed as a single logical message.
+// Since UDP has no connection to gate up front, the first datagram from
+// each peer must be the ::auth:: handshake whenever auth is required;
+// later datagrams from an already-authenticated peer are accepted freely.
+func serveUDP(p *tea.Program, cfg listenerConfig, auth authConfig) {
+	conn, err := net.ListenPacket("udp", connHost+":"+cfg.addr)
+	if err != nil {
+		fmt.Println("Error listening:", err.Error())
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	authenticated := map[string]bool{}
+	names := map[string]string{}
+	seenPeers := map[string]bool{}
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			fmt.Println("Error reading datagram:", err.Error())
+			return
+		}
+
+		addr := peer.String()
+		str := strings.TrimRight(string(buf[:n]), "\n")
+
+		if auth.required() && !authenticated[addr] {
+			if !auth.verify(str) {
+				continue
+			}
+			authenticated[addr] = true
+			continue
+		}
+
+		if index := strings.Index(str, "::name::"); index != -1 {
+			names[addr] = strings.TrimSpace(str[index+8:])
+			p.Send(sourceConnected{addr: addr, name: names[addr]})
+			continue
+		}
+
+		name := cfg.name
+		if stored, ok := names[addr]; ok {
+			name = stored
+		}
+		src := sourceConn{addr: addr, name: name, format: cfg.format}
+		if !seenPeers[addr] {
+			seenPeers[addr] = true
+			p.Send(sourceConnected{addr: addr, name: displayName(src)})
+		}
+		dispatchLine(src, str)
+	}
+}
+
+// dispatchLine parses, persists, and queues one application-level line as a
+// remoteMessage, shared by every listener transport. It's pushed onto
+// msgBatcher rather than sent to the program directly, so a burst of
+// traffic from many connections coalesces into one render per tick instead
+// of outrunning the renderer.
+func dispatchLine(src sourceConn, str string) {
+	now := time.Now()
+	if sessionWriter != nil {
+		sessionWriter.Write(store.Record{At: now, Source: src.name, RemoteAddr: src.addr, Format: src.format, Raw: str})
+	}
+
+	parsed := parseLine(str, src.format)
+	msgBatcher.push(remoteMessage{name: displayName(src) + ": ", addr: src.addr, message: parsed.msg, level: parsed.level, fields: parsed.fields, at: now})
+}
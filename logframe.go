@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// logLevel is the severity extracted from a structured log line, used to
+// pick the style it's rendered with.
+type logLevel string
+
+const (
+	levelDebug   logLevel = "debug"
+	levelInfo    logLevel = "info"
+	levelWarn    logLevel = "warn"
+	levelError   logLevel = "error"
+	levelFatal   logLevel = "fatal"
+	levelUnknown logLevel = ""
+)
+
+// parsedLine is the result of framing one inbound line: its severity (if
+// any was detected), the human-readable message, and any structured
+// key/value fields that came with it.
+type parsedLine struct {
+	level  logLevel
+	msg    string
+	fields map[string]string
+}
+
+// syslogPattern matches the RFC5424 header:
+// "<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID ".
+var syslogPattern = regexp.MustCompile(`^<(\d+)>\d+ \S+ \S+ \S+ \S+ \S+ (.*)$`)
+
+// logfmtPattern pulls key=value and key="quoted value" pairs out of a line.
+var logfmtPattern = regexp.MustCompile(`(\w+)=("([^"\\]|\\.)*"|\S+)`)
+
+// detectFormat guesses the structured format of a single inbound line.
+func detectFormat(line string) string {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)):
+		return "json"
+	case syslogPattern.MatchString(trimmed):
+		return "syslog"
+	case logfmtPattern.MatchString(trimmed):
+		return "logfmt"
+	default:
+		return "raw"
+	}
+}
+
+// parseLine frames a raw inbound line according to format, falling back to
+// a raw parsedLine (no level, no fields) for anything it can't parse.
+func parseLine(line string, format string) parsedLine {
+	if format == "auto" {
+		format = detectFormat(line)
+	}
+
+	switch format {
+	case "json":
+		if pl, ok := parseJSONLine(line); ok {
+			return pl
+		}
+	case "logfmt":
+		if pl, ok := parseLogfmtLine(line); ok {
+			return pl
+		}
+	case "syslog":
+		if pl, ok := parseSyslogLine(line); ok {
+			return pl
+		}
+	}
+
+	return parsedLine{level: levelUnknown, msg: line}
+}
+
+func normalizeLevel(raw string) logLevel {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug", "dbg", "trace":
+		return levelDebug
+	case "info", "information":
+		return levelInfo
+	case "warn", "warning":
+		return levelWarn
+	case "error", "err":
+		return levelError
+	case "fatal", "panic", "critical":
+		return levelFatal
+	default:
+		return levelUnknown
+	}
+}
+
+func parseJSONLine(line string) (parsedLine, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return parsedLine{}, false
+	}
+
+	pl := parsedLine{fields: map[string]string{}}
+	for key, value := range raw {
+		switch key {
+		case "level":
+			pl.level = normalizeLevel(toString(value))
+		case "msg", "message":
+			pl.msg = toString(value)
+		case "ts", "time", "timestamp":
+			// surfaced via the connection timestamp instead, so skip here.
+		default:
+			if nested, ok := value.(map[string]interface{}); ok && key == "fields" {
+				for nk, nv := range nested {
+					pl.fields[nk] = toString(nv)
+				}
+				continue
+			}
+			pl.fields[key] = toString(value)
+		}
+	}
+
+	return pl, true
+}
+
+func parseLogfmtLine(line string) (parsedLine, bool) {
+	matches := logfmtPattern.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return parsedLine{}, false
+	}
+
+	pl := parsedLine{fields: map[string]string{}}
+	for _, m := range matches {
+		key, value := m[1], strings.Trim(m[2], `"`)
+		switch key {
+		case "level", "lvl":
+			pl.level = normalizeLevel(value)
+		case "msg", "message":
+			pl.msg = value
+		default:
+			pl.fields[key] = value
+		}
+	}
+
+	// A logfmt line with no msg/message key has nothing
+	// left to show but its key=value pairs, which are already captured in
+	// pl.fields and rendered from there — falling back to the raw line here
+	// would print them a second time.
+	return pl, true
+}
+
+func parseSyslogLine(line string) (parsedLine, bool) {
+	m := syslogPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return parsedLine{}, false
+	}
+
+	pri, err := strconv.Atoi(m[1])
+	if err != nil {
+		return parsedLine{}, false
+	}
+
+	return parsedLine{level: severityToLevel(pri % 8), msg: m[2]}, true
+}
+
+// severityToLevel maps an RFC5424 severity (0-7) onto our coarser levels.
+func severityToLevel(severity int) logLevel {
+	switch {
+	case severity <= 2:
+		return levelFatal
+	case severity == 3:
+		return levelError
+	case severity == 4:
+		return levelWarn
+	case severity <= 6:
+		return levelInfo
+	default:
+		return levelDebug
+	}
+}
+
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
@@ -1,18 +1,22 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"net"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/indent"
 	"github.com/muesli/reflow/wordwrap"
 	"github.com/muesli/reflow/wrap"
+
+	"github.com/csterritt/debug-listener/store"
 )
 
 // You generally won't need this unless you're processing stuff with
@@ -25,7 +29,6 @@ const (
 	// sockets information
 	connHost = "localhost"
 	connPort = "21212"
-	connType = "tcp"
 
 	// bubbletea
 	useHighPerformanceRenderer = false
@@ -44,71 +47,117 @@ var (
 		return titleStyle.Copy().BorderStyle(b)
 	}()
 
-	boldStyle = lipgloss.NewStyle().
-			Bold(true)
+	// sourcePalette assigns a rotating lipgloss color to each connection so
+	// concurrent sources stay visually distinguishable.
+	sourcePalette = []lipgloss.Color{"6", "2", "5", "3", "4", "9", "13", "14"}
+
+	levelStyles = map[logLevel]lipgloss.Style{
+		levelDebug: lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		levelInfo:  lipgloss.NewStyle(),
+		levelWarn:  lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+		levelError: lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		levelFatal: lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true),
+	}
 )
 
 type model struct {
-	content  string
-	ready    bool
-	viewport viewport.Model
+	content      string
+	ready        bool
+	viewport     viewport.Model
+	sourceColors map[string]lipgloss.Color
+
+	// messages is the full, unfiltered log; content is re-derived from it
+	// whenever messages arrive or the filter, pinned-source, or
+	// source-visibility state changes.
+	messages []remoteMessage
+	paused   bool
+
+	sourceOrder   []string
+	hiddenSources map[string]bool
+
+	// typingFilter holds the filter kind ("substring" or "fuzzy") while
+	// filterInput is focused and capturing a query; it's cleared once the
+	// query is committed (or cancelled) into filterMode/filterQuery.
+	typingFilter string
+	filterInput  textinput.Model
+	filterMode   string
+	filterQuery  string
+	matches      []int
+	matchLines   []int
+	matchCursor  int
+
+	// statusMessage surfaces the result of the last s/S/e save or the last
+	// session-log error, shown in the footer until the next one replaces it.
+	statusMessage string
+
+	// Split-pane source list: sourceStates/sourceAddrs back the left-hand
+	// list.Model, pinnedSource narrows the right-hand viewport to one
+	// source, and focus says which pane keyboard input currently drives.
+	sourceStates       map[string]*sourceState
+	sourceAddrs        []string
+	sourceList         list.Model
+	focus              string
+	pinnedSource       string
+	recentMessageTimes []time.Time
+}
+
+const (
+	focusDetail = "detail"
+	focusList   = "list"
+)
+
+// sourceConn tracks the peer, chosen name, and log format for one connected
+// client. format is the format configured on its listener; when that is
+// "auto", each line's format is detected as it is parsed and is not stored
+// here.
+type sourceConn struct {
+	addr   string
+	name   string
+	format string
 }
 
 type remoteMessage struct {
 	name    string
+	addr    string
 	message string
+	level   logLevel
+	fields  map[string]string
+	at      time.Time
 }
 
 func initialModel() model {
-	return model{
-		content: "",
-	}
-}
-
-func handleConnection(conn net.Conn, p *tea.Program) {
-	reader := bufio.NewReader(conn)
-	name := ""
+	input := textinput.New()
+	input.Prompt = "/"
+	input.CharLimit = 200
 
-	for {
-		buffer, err := reader.ReadBytes('\n')
+	sourceList := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	sourceList.Title = "Sources"
+	sourceList.SetShowHelp(false)
 
-		if err != nil {
-			conn.Close()
-			return
-		}
-
-		str := string(buffer[:len(buffer)-1])
-		if index := strings.Index(str, "::name::"); index != -1 {
-			name = strings.TrimSpace(str[index+8:]) + ": "
-		} else {
-			p.Send(remoteMessage{name: name, message: str})
-		}
+	return model{
+		content:       "",
+		sourceColors:  map[string]lipgloss.Color{},
+		hiddenSources: map[string]bool{},
+		filterInput:   input,
+		sourceStates:  map[string]*sourceState{},
+		sourceList:    sourceList,
+		focus:         focusDetail,
 	}
 }
 
-func startListener(p *tea.Program) {
-	go func() {
-		l, err := net.Listen(connType, connHost+":"+connPort)
-		if err != nil {
-			fmt.Println("Error listening to port:", err.Error())
-			os.Exit(1)
-		}
-		defer l.Close()
-
-		for {
-			c, err := l.Accept()
-			if err != nil {
-				fmt.Println("Error connecting:", err.Error())
-				return
-			}
-
-			go handleConnection(c, p)
-		}
-	}()
+// colorFor returns the stable palette color for a source name, assigning
+// the next unused slot the first time a name is seen.
+func (m *model) colorFor(name string) lipgloss.Color {
+	if color, ok := m.sourceColors[name]; ok {
+		return color
+	}
+	color := sourcePalette[len(m.sourceColors)%len(sourcePalette)]
+	m.sourceColors[name] = color
+	return color
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return tickCmd()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -121,6 +170,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Is it a key press?
 	case tea.KeyMsg:
+		if m.typingFilter != "" {
+			switch msg.String() {
+			case "enter":
+				m.filterMode = m.typingFilter
+				m.filterQuery = m.filterInput.Value()
+				m.typingFilter = ""
+				m.filterInput.Blur()
+				m.recomputeMatches()
+				m.content = m.renderAll()
+				m.viewport.SetContent(m.content)
+				return m, nil
+
+			case "esc":
+				m.typingFilter = ""
+				m.filterInput.Blur()
+				return m, nil
+
+			default:
+				m.filterInput, cmd = m.filterInput.Update(msg)
+				return m, cmd
+			}
+		}
+
 		// Cool, what was the actual key pressed?
 		switch msg.String() {
 
@@ -130,8 +202,99 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "c":
 			m.content = ""
+			m.messages = nil
+			m.matches = nil
+			m.matchLines = nil
 			m.viewport.SetContent("")
 			return m, nil
+
+		case "/":
+			m.typingFilter = "substring"
+			m.filterInput.Prompt = "/"
+			m.filterInput.SetValue("")
+			m.filterInput.Focus()
+			return m, textinput.Blink
+
+		case "f":
+			m.typingFilter = "fuzzy"
+			m.filterInput.Prompt = "fuzzy>"
+			m.filterInput.SetValue("")
+			m.filterInput.Focus()
+			return m, textinput.Blink
+
+		case "p":
+			m.paused = !m.paused
+			return m, nil
+
+		case "n":
+			if len(m.matchLines) > 0 {
+				m.matchCursor = (m.matchCursor + 1) % len(m.matchLines)
+				m.jumpToMatch()
+			}
+			return m, nil
+
+		case "N":
+			if len(m.matchLines) > 0 {
+				m.matchCursor = (m.matchCursor - 1 + len(m.matchLines)) % len(m.matchLines)
+				m.jumpToMatch()
+			}
+			return m, nil
+
+		case "g":
+			m.viewport.GotoTop()
+			return m, nil
+
+		case "G":
+			m.viewport.GotoBottom()
+			return m, nil
+
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			m.toggleSource(int(msg.String()[0] - '0'))
+			m.recomputeMatches()
+			m.content = m.renderAll()
+			m.viewport.SetContent(m.content)
+			return m, nil
+
+		case "s":
+			path, err := saveText(m.content, ".txt", time.Now())
+			m.statusMessage = saveStatus("saved", path, err)
+			return m, nil
+
+		case "S":
+			path, err := saveHTML(m.content, time.Now())
+			m.statusMessage = saveStatus("saved", path, err)
+			return m, nil
+
+		case "e":
+			path, err := saveText(m.filteredContent(), ".txt", time.Now())
+			m.statusMessage = saveStatus("exported", path, err)
+			return m, nil
+
+		case "tab":
+			if m.focus == focusList {
+				m.focus = focusDetail
+			} else {
+				m.focus = focusList
+			}
+			return m, nil
+
+		case "enter":
+			if m.focus == focusList {
+				if item, ok := m.sourceList.SelectedItem().(sourceItem); ok {
+					m.pinnedSource = item.state.name
+					m.recomputeMatches()
+					m.content = m.renderAll()
+					m.viewport.SetContent(m.content)
+				}
+				return m, nil
+			}
+
+		case "a":
+			m.pinnedSource = ""
+			m.recomputeMatches()
+			m.content = m.renderAll()
+			m.viewport.SetContent(m.content)
+			return m, nil
 		}
 
 	case tea.WindowSizeMsg:
@@ -139,13 +302,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		footerHeight := lipgloss.Height(m.footerView())
 		verticalMarginHeight := headerHeight + footerHeight
 
+		listWidth := msg.Width / 4
+		if listWidth > 32 {
+			listWidth = 32
+		}
+		detailWidth := msg.Width - listWidth - 1
+		paneHeight := msg.Height - verticalMarginHeight
+
+		m.sourceList.SetSize(listWidth, paneHeight)
+
 		if !m.ready {
 			// Since this program is using the full size of the viewport we
 			// need to wait until we've received the window dimensions before
 			// we can initialize the viewport. The initial dimensions come in
 			// quickly, though asynchronously, which is why we wait for them
 			// here.
-			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
+			m.viewport = viewport.New(detailWidth, paneHeight)
 			m.viewport.YPosition = headerHeight
 			m.viewport.HighPerformanceRendering = useHighPerformanceRenderer
 			m.viewport.SetContent(m.content)
@@ -157,8 +329,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Render the viewport one line below the header.
 			m.viewport.YPosition = headerHeight + 1
 		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - verticalMarginHeight
+			m.viewport.Width = detailWidth
+			m.viewport.Height = paneHeight
 		}
 
 		if useHighPerformanceRenderer {
@@ -170,45 +342,170 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case remoteMessage:
-		if len(msg.message) > 0 {
-			output := msg.message
-			if len(msg.name) > 0 {
-				output = boldStyle.Render(msg.name) + msg.message
+		if m.appendMessage(msg) {
+			m.refreshAfterMessages()
+		}
+		return m, nil
+
+	case batchMessage:
+		var changed bool
+		for _, one := range msg {
+			if m.appendMessage(one) {
+				changed = true
 			}
-			output = wordwrap.String(output, m.viewport.Width-(4+len(msg.name)))
-			output = indent.String(" "+output, 4)
-			output = strings.TrimLeft(output, " ")
-			m.content += wrap.String(output+"\n", m.viewport.Width)
-			m.viewport.SetContent(m.content)
 		}
+		if changed {
+			m.refreshAfterMessages()
+		}
+		return m, nil
+
+	case sourceConnected:
+		m.upsertSource(msg.addr, msg.name)
+		m.refreshSourceList()
+		return m, nil
+
+	case sourceDisconnected:
+		if state, ok := m.sourceStates[msg.addr]; ok {
+			state.connected = false
+			m.refreshSourceList()
+		}
+		return m, nil
+
+	case tickMsg:
+		m.pruneThroughput(time.Time(msg))
+		return m, tickCmd()
+
+	case storeErrorMsg:
+		m.statusMessage = string(msg)
 		return m, nil
 	}
 
-	// Handle keyboard and mouse events in the viewport
-	m.viewport, cmd = m.viewport.Update(msg)
+	// Handle keyboard and mouse events in the focused pane
+	if key, ok := msg.(tea.KeyMsg); ok && m.focus == focusList {
+		m.sourceList, cmd = m.sourceList.Update(key)
+	} else {
+		m.viewport, cmd = m.viewport.Update(msg)
+	}
 	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
 
+// appendMessage records one remoteMessage's bookkeeping (source tracking,
+// throughput, the message log itself) without re-rendering, so a batch of
+// messages can be folded in with a single render pass afterward. It reports
+// whether msg carried any content worth rendering.
+func (m *model) appendMessage(msg remoteMessage) bool {
+	if len(msg.message) == 0 && len(msg.fields) == 0 {
+		return false
+	}
+
+	m.trackSource(sourceName(msg))
+	if state, ok := m.sourceStates[msg.addr]; ok {
+		state.msgCount++
+		state.lastSeen = msg.at
+		m.refreshSourceList()
+	}
+	m.recordThroughput(msg.at)
+	m.messages = append(m.messages, msg)
+	return true
+}
+
+// refreshAfterMessages re-derives the viewport content after one or more
+// appendMessage calls and scrolls to the bottom unless paused.
+func (m *model) refreshAfterMessages() {
+	if m.filterMode != "" {
+		m.recomputeMatches()
+	}
+	m.content = m.renderAll()
+	m.viewport.SetContent(m.content)
+	if !m.paused {
+		m.viewport.GotoBottom()
+	}
+}
+
 func (m model) View() string {
 	if !m.ready {
 		return "\n  Initializing..."
 	}
 
+	body := lipgloss.JoinHorizontal(lipgloss.Top, m.sourceList.View(), m.viewport.View())
+
+	if m.typingFilter != "" {
+		return fmt.Sprintf("%s\n%s\n%s", m.headerView(), body, m.filterInput.View())
+	}
+
 	// Send the UI for rendering
-	return fmt.Sprintf("%s\n%s\n%s", m.headerView(), m.viewport.View(), m.footerView())
+	return fmt.Sprintf("%s\n%s\n%s", m.headerView(), body, m.footerView())
+}
+
+// renderMessage formats one remoteMessage as "[hh:mm:ss][name][LEVEL] msg",
+// styling the name by its assigned source color and the level by severity,
+// then wraps the result (plus any structured fields, indented) to the
+// viewport width. When highlighted is set (an active filter matched this
+// message), reverse-video is folded into every sub-style individually
+// rather than wrapped around the already-rendered line: each styled run
+// (name, level) ends in its own ANSI reset, so wrapping the whole thing in
+// one outer style afterward would have the later resets cancel it out
+// partway through the line.
+func (m *model) renderMessage(msg remoteMessage, highlighted bool) string {
+	plain := lipgloss.NewStyle().Reverse(highlighted)
+	nameStyle := lipgloss.NewStyle().Bold(true).Foreground(m.colorFor(msg.name)).Reverse(highlighted)
+
+	prefix := plain.Render(fmt.Sprintf("[%s]", msg.at.Format("15:04:05")))
+	prefix += nameStyle.Render("[" + strings.TrimSuffix(msg.name, ": ") + "]")
+	if msg.level != levelUnknown {
+		style := levelStyles[msg.level].Copy().Reverse(highlighted)
+		prefix += style.Render("[" + strings.ToUpper(string(msg.level)) + "]")
+	}
+
+	output := prefix + plain.Render(" "+msg.message)
+	output = wordwrap.String(output, m.viewport.Width-4)
+	output = indent.String(output, 4)
+	output = strings.TrimLeft(output, " ")
+
+	keys := make([]string, 0, len(msg.fields))
+	for key := range msg.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := indent.String(fmt.Sprintf("%s=%s", key, msg.fields[key]), 6)
+		output += "\n" + plain.Render(wrap.String(field, m.viewport.Width))
+	}
+
+	return wrap.String(output+"\n", m.viewport.Width)
 }
 
 func (m model) headerView() string {
-	title := titleStyle.Render("Debug listener -- Press q to quit, c to clear the output area.")
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
+	heading := fmt.Sprintf("Debug listener -- %d active source(s), %.1f msg/s -- Tab: switch pane, Enter: pin, a: all, q: quit",
+		m.activeSourceCount(), m.throughput())
+	if m.pinnedSource != "" {
+		heading = fmt.Sprintf("Debug listener -- pinned to %q -- a: all sources, q: quit", m.pinnedSource)
+	}
+
+	title := titleStyle.Render(heading)
+	width := m.viewport.Width + lipgloss.Width(m.sourceList.View())
+	line := strings.Repeat("─", max(0, width-lipgloss.Width(title)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (m model) footerView() string {
-	info := infoStyle.Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
+	status := fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100)
+	if m.statusMessage != "" {
+		status = m.statusMessage + " " + status
+	}
+	if m.paused {
+		status = "PAUSED " + status
+	}
+	if m.filterMode != "" {
+		status = fmt.Sprintf("%s:%q (%d/%d) ", m.filterMode, m.filterQuery, m.matchCursor+1, len(m.matchLines)) + status
+	}
+
+	info := infoStyle.Render(status)
+	width := m.viewport.Width + lipgloss.Width(m.sourceList.View())
+	line := strings.Repeat("─", max(0, width-lipgloss.Width(info)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
 
@@ -219,12 +516,54 @@ func max(a, b int) int {
 	return b
 }
 
-var p *tea.Program
+// storeErrorMsg carries a session-log write/drop failure into the TUI, so
+// it can be surfaced via statusMessage instead of being written straight to
+// stderr and corrupting the alt screen.
+type storeErrorMsg string
+
+// forwardStoreErrors relays w's Errors channel into p as storeErrorMsg
+// events for as long as the program runs.
+func forwardStoreErrors(p *tea.Program, w *store.Writer) {
+	go func() {
+		for msg := range w.Errors() {
+			p.Send(storeErrorMsg(msg))
+		}
+	}()
+}
+
+var (
+	p             *tea.Program
+	sessionWriter *store.Writer
+	msgBatcher    *batcher
+)
 
 func main() {
+	cfg := mustParseAppConfig(os.Args[1:])
+
+	writer, err := store.NewWriter("", time.Now(), cfg.maxBuffer)
+	if err != nil {
+		fmt.Println("Error opening session log:", err.Error())
+		os.Exit(1)
+	}
+	sessionWriter = writer
+	defer sessionWriter.Close()
+
+	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if terminalSupportsSyncedOutput() {
+		opts = append(opts, tea.WithOutput(syncedWriter{w: os.Stdout}))
+	}
+
 	model := initialModel()
-	p = tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
-	startListener(p)
+	p = tea.NewProgram(model, opts...)
+
+	msgBatcher = newBatcher(cfg.maxBuffer, cfg.dropPolicy)
+	startBatcher(p, msgBatcher)
+	forwardStoreErrors(p, sessionWriter)
+
+	startListener(p, cfg.listeners, cfg.auth, cfg.tls, cfg.socketMode)
+	if cfg.replayPath != "" {
+		startReplay(p, cfg.replayPath, cfg.replayFast)
+	}
 	if err := p.Start(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// syncStart/syncEnd are the terminal "synchronized output" private mode
+// escapes (DECSET/DECRST 2026, aka BSU/ESU): bracketing a frame in them
+// tells a supporting terminal to apply the whole write atomically, instead
+// of painting it as the bytes arrive, which is what causes visible tearing
+// when messages are arriving faster than a frame can be drawn.
+const (
+	syncStart = "\x1b[?2026h"
+	syncEnd   = "\x1b[?2026l"
+)
+
+// syncedWriter wraps an io.Writer and brackets every write in the
+// synchronized-output escape. Terminals that don't understand DECSET 2026
+// just ignore it, so this is safe to use speculatively.
+type syncedWriter struct {
+	w io.Writer
+}
+
+func (s syncedWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(s.w, syncStart); err != nil {
+		return 0, err
+	}
+	n, err := s.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if _, err := io.WriteString(s.w, syncEnd); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// terminalSupportsSyncedOutput makes a best-effort guess, from the
+// environment, at whether the terminal honors the synchronized-output
+// mode. There's no portable terminfo query for it, so this only gates
+// whether we bother sending the escape at all.
+func terminalSupportsSyncedOutput() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty":
+		return true
+	}
+	if strings.Contains(os.Getenv("TERM"), "kitty") || os.Getenv("KITTY_WINDOW_ID") != "" {
+		return true
+	}
+	// tmux passes the escape through to whatever terminal hosts it.
+	return os.Getenv("TMUX") != ""
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/csterritt/debug-listener/store"
+)
+
+// startReplay loads path and feeds its records into msgBatcher as
+// remoteMessages, one at a time, the same as a live connection would.
+// Unless fast is set, it sleeps between records to reproduce the original
+// inter-arrival delays; routing through msgBatcher rather than p.Send
+// keeps --replay-fast from reintroducing the per-message renderer flood
+// the batching layer exists to avoid.
+func startReplay(p *tea.Program, path string, fast bool) {
+	records, err := store.LoadSession(path)
+	if err != nil {
+		fmt.Println("Error loading replay session:", err.Error())
+		os.Exit(1)
+	}
+
+	go func() {
+		announced := map[string]bool{}
+		var last time.Time
+		for _, rec := range records {
+			if !fast && !last.IsZero() {
+				if delay := rec.At.Sub(last); delay > 0 {
+					time.Sleep(delay)
+				}
+			}
+			last = rec.At
+
+			name := rec.Source
+			if name == "" {
+				name = rec.RemoteAddr
+			}
+			if !announced[rec.RemoteAddr] {
+				announced[rec.RemoteAddr] = true
+				p.Send(sourceConnected{addr: rec.RemoteAddr, name: name})
+			}
+
+			parsed := parseLine(rec.Raw, rec.Format)
+			msgBatcher.push(remoteMessage{name: name + ": ", addr: rec.RemoteAddr, message: parsed.msg, level: parsed.level, fields: parsed.fields, at: rec.At})
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sourceState tracks one connected peer for the source list pane: its
+// address, chosen name, and activity stats.
+type sourceState struct {
+	addr      string
+	name      string
+	msgCount  int
+	lastSeen  time.Time
+	connected bool
+}
+
+// sourceConnected/sourceDisconnected are sent by the listeners when a peer
+// connects, renames itself via ::name::, or drops off.
+type sourceConnected struct {
+	addr string
+	name string
+}
+
+type sourceDisconnected struct {
+	addr string
+}
+
+// sourceItem adapts a *sourceState to bubbles/list.Item.
+type sourceItem struct {
+	state *sourceState
+}
+
+func (i sourceItem) Title() string {
+	title := i.state.name
+	if title == "" {
+		title = i.state.addr
+	}
+	if !i.state.connected {
+		title += " (gone)"
+	}
+	return title
+}
+
+func (i sourceItem) Description() string {
+	return fmt.Sprintf("%s · %d msgs · last %s", i.state.addr, i.state.msgCount, i.state.lastSeen.Format("15:04:05"))
+}
+
+func (i sourceItem) FilterValue() string {
+	return i.state.name
+}
+
+// upsertSource creates or updates the tracked state for addr, keeping the
+// source list in insertion order.
+func (m *model) upsertSource(addr, name string) *sourceState {
+	if state, ok := m.sourceStates[addr]; ok {
+		state.name = name
+		state.connected = true
+		return state
+	}
+
+	state := &sourceState{addr: addr, name: name, connected: true}
+	m.sourceStates[addr] = state
+	m.sourceAddrs = append(m.sourceAddrs, addr)
+	return state
+}
+
+// refreshSourceList rebuilds the list.Model's items from current source
+// state, preserving the selected item where possible.
+func (m *model) refreshSourceList() {
+	items := make([]list.Item, len(m.sourceAddrs))
+	for i, addr := range m.sourceAddrs {
+		items[i] = sourceItem{state: m.sourceStates[addr]}
+	}
+	m.sourceList.SetItems(items)
+}
+
+// activeSourceCount returns how many tracked sources are still connected.
+func (m model) activeSourceCount() int {
+	count := 0
+	for _, state := range m.sourceStates {
+		if state.connected {
+			count++
+		}
+	}
+	return count
+}
+
+// recordThroughput logs a message arrival at "at" and prunes the window.
+func (m *model) recordThroughput(at time.Time) {
+	m.recentMessageTimes = append(m.recentMessageTimes, at)
+	m.pruneThroughput(at)
+}
+
+// pruneThroughput drops message timestamps older than the 5s window.
+func (m *model) pruneThroughput(now time.Time) {
+	cutoff := now.Add(-5 * time.Second)
+	i := 0
+	for i < len(m.recentMessageTimes) && m.recentMessageTimes[i].Before(cutoff) {
+		i++
+	}
+	m.recentMessageTimes = m.recentMessageTimes[i:]
+}
+
+// throughput returns the current rate in messages/sec, averaged over the
+// trailing 5s window.
+func (m model) throughput() float64 {
+	return float64(len(m.recentMessageTimes)) / 5.0
+}
+
+// tickMsg drives the periodic re-render needed to keep throughput and
+// last-seen times current even when nothing new has arrived.
+type tickMsg time.Time
+
+func tickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
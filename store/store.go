@@ -0,0 +1,144 @@
+// Package store persists received messages to a per-day, line-delimited
+// JSON session log on disk and loads them back for --replay. The file is
+// named by the date the Writer was opened; a Writer that is still running
+// after midnight keeps appending to that same file.
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Record is one persisted message: enough to re-render it and, on replay,
+// to recreate the original inter-arrival timing.
+type Record struct {
+	At         time.Time `json:"at"`
+	Source     string    `json:"source"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Format     string    `json:"format"`
+	Raw        string    `json:"raw"`
+}
+
+// Writer streams Records to a session file, one JSON object per line, via
+// a single goroutine so concurrent connections can write without locking.
+type Writer struct {
+	records chan Record
+	done    chan struct{}
+	errs    chan string
+}
+
+// sessionDir is the default home for session files: ~/.debug-listener/sessions.
+func sessionDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".debug-listener", "sessions"), nil
+}
+
+// NewWriter opens (creating if necessary) today's session file under dir
+// and starts the writer goroutine. Pass "" for dir to use the default
+// ~/.debug-listener/sessions location. bufferSize caps how many pending
+// records the writer will queue before Write starts dropping them — callers
+// should size this off the same buffer knob used for the render path, so
+// the writer that fills up first isn't left with a fixed, unconfigurable
+// limit.
+func NewWriter(dir string, now time.Time, bufferSize int) (*Writer, error) {
+	if dir == "" {
+		var err error
+		dir, err = sessionDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, now.Format("2006-01-02")+".ndjson")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{records: make(chan Record, bufferSize), done: make(chan struct{}), errs: make(chan string, 16)}
+	go w.run(f)
+	return w, nil
+}
+
+func (w *Writer) run(f *os.File) {
+	defer f.Close()
+	defer close(w.done)
+
+	enc := json.NewEncoder(f)
+	for rec := range w.records {
+		if err := enc.Encode(rec); err != nil {
+			w.reportError(fmt.Sprintf("store: write error: %s", err))
+		}
+	}
+}
+
+// Write queues rec to be persisted. It never blocks the caller for long:
+// the channel is buffered, and a full buffer just drops the record rather
+// than stall the connection goroutine that's reading off the wire.
+func (w *Writer) Write(rec Record) {
+	select {
+	case w.records <- rec:
+	default:
+		w.reportError("store: buffer full, dropping record")
+	}
+}
+
+// reportError queues msg onto Errors, dropping it rather than blocking if
+// nobody's draining the channel fast enough.
+func (w *Writer) reportError(msg string) {
+	select {
+	case w.errs <- msg:
+	default:
+	}
+}
+
+// Errors reports write/buffer failures. Callers shouldn't write these
+// straight to stderr: with the TUI on the alt screen, that corrupts the
+// display instead of surfacing the problem. Forward them into the program
+// as a tea.Msg instead.
+func (w *Writer) Errors() <-chan string {
+	return w.errs
+}
+
+// Close stops accepting records and waits for the writer goroutine to
+// flush and close the underlying file.
+func (w *Writer) Close() {
+	close(w.records)
+	<-w.done
+}
+
+// LoadSession reads every Record from an ndjson session file, in order.
+func LoadSession(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("store: parsing %s: %w", path, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}